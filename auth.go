@@ -0,0 +1,142 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+)
+
+// AuthProvider supplies the bearer token used to authenticate requests.
+// Login is called once at startup; Refresh is called after a 401 to obtain
+// a new token before the request is retried.
+type AuthProvider interface {
+	Login() (string, error)
+	Refresh() (string, error)
+}
+
+// TokenCache holds the current bearer token, shared by every worker
+// goroutine so a refresh from one is visible to all subsequent requests.
+type TokenCache struct {
+	mu    sync.RWMutex
+	token string
+}
+
+func NewTokenCache(initial string) *TokenCache {
+	return &TokenCache{token: initial}
+}
+
+func (t *TokenCache) Get() string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.token
+}
+
+func (t *TokenCache) Set(token string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.token = token
+}
+
+// EmailPasswordAuth is the tool's original auth flow: POST email/password to
+// the target's /users/login route.
+type EmailPasswordAuth struct {
+	Email       string
+	Password    string
+	EndpointURL string
+}
+
+func (a *EmailPasswordAuth) Login() (string, error) {
+	return login(a.Email, a.Password, a.EndpointURL)
+}
+
+func (a *EmailPasswordAuth) Refresh() (string, error) {
+	return a.Login()
+}
+
+// StaticTokenAuth uses a fixed bearer token supplied via --token or
+// $AUTH_TOKEN. It cannot refresh, so Refresh returns the same token.
+type StaticTokenAuth struct {
+	Token string
+}
+
+func (a *StaticTokenAuth) Login() (string, error) {
+	return a.Token, nil
+}
+
+func (a *StaticTokenAuth) Refresh() (string, error) {
+	return a.Token, nil
+}
+
+// NoAuth sends every request without an Authorization header.
+type NoAuth struct{}
+
+func (NoAuth) Login() (string, error)   { return "", nil }
+func (NoAuth) Refresh() (string, error) { return "", nil }
+
+// OAuth2ClientCredentialsAuth exchanges a client id/secret for a bearer
+// token via the OAuth2 client_credentials grant.
+type OAuth2ClientCredentialsAuth struct {
+	ClientID     string
+	ClientSecret string
+	TokenURL     string
+}
+
+type oauthTokenResponse struct {
+	AccessToken string `json:"access_token"`
+}
+
+func (a *OAuth2ClientCredentialsAuth) Login() (string, error) {
+	form := url.Values{}
+	form.Set("grant_type", "client_credentials")
+	form.Set("client_id", a.ClientID)
+	form.Set("client_secret", a.ClientSecret)
+
+	resp, err := http.PostForm(a.TokenURL, form)
+	if err != nil {
+		return "", fmt.Errorf("error requesting oauth token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("oauth token request failed with status code: %d", resp.StatusCode)
+	}
+
+	var tokenResp oauthTokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&tokenResp); err != nil {
+		return "", fmt.Errorf("error decoding oauth token response: %w", err)
+	}
+
+	return tokenResp.AccessToken, nil
+}
+
+func (a *OAuth2ClientCredentialsAuth) Refresh() (string, error) {
+	return a.Login()
+}
+
+// selectAuthProvider picks an AuthProvider from the flags the user set,
+// preferring the most explicit option: a static token, then OAuth2 client
+// credentials, then email/password, falling back to no auth at all.
+func selectAuthProvider(config Config) (AuthProvider, error) {
+	switch {
+	case config.Token != "":
+		return &StaticTokenAuth{Token: config.Token}, nil
+	case config.OAuthClientID != "" || config.OAuthClientSecret != "" || config.OAuthTokenURL != "":
+		if config.OAuthClientID == "" || config.OAuthClientSecret == "" || config.OAuthTokenURL == "" {
+			return nil, fmt.Errorf("oauth auth requires --oauth-client-id, --oauth-client-secret, and --oauth-token-url")
+		}
+		return &OAuth2ClientCredentialsAuth{
+			ClientID:     config.OAuthClientID,
+			ClientSecret: config.OAuthClientSecret,
+			TokenURL:     config.OAuthTokenURL,
+		}, nil
+	case config.Email != "" || config.Password != "":
+		if config.Email == "" || config.Password == "" {
+			return nil, fmt.Errorf("email/password auth requires both --email and --password")
+		}
+		return &EmailPasswordAuth{Email: config.Email, Password: config.Password, EndpointURL: config.EndpointURL}, nil
+	default:
+		return NoAuth{}, nil
+	}
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOAuth2ClientCredentialsAuthLogin(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("method = %s, want POST", r.Method)
+		}
+		if ct := r.Header.Get("Content-Type"); ct != "application/x-www-form-urlencoded" {
+			t.Errorf("Content-Type = %q, want application/x-www-form-urlencoded", ct)
+		}
+		if err := r.ParseForm(); err != nil {
+			t.Fatalf("ParseForm: %v", err)
+		}
+		if got := r.FormValue("grant_type"); got != "client_credentials" {
+			t.Errorf("grant_type = %q, want client_credentials", got)
+		}
+		if got := r.FormValue("client_id"); got != "my-client" {
+			t.Errorf("client_id = %q, want my-client", got)
+		}
+		if got := r.FormValue("client_secret"); got != "my-secret" {
+			t.Errorf("client_secret = %q, want my-secret", got)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"access_token": "issued-token"})
+	}))
+	defer server.Close()
+
+	auth := &OAuth2ClientCredentialsAuth{ClientID: "my-client", ClientSecret: "my-secret", TokenURL: server.URL}
+	token, err := auth.Login()
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	if token != "issued-token" {
+		t.Errorf("token = %q, want issued-token", token)
+	}
+}
+
+func TestOAuth2ClientCredentialsAuthLoginNonOKStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	auth := &OAuth2ClientCredentialsAuth{ClientID: "id", ClientSecret: "secret", TokenURL: server.URL}
+	if _, err := auth.Login(); err == nil {
+		t.Fatal("Login: expected an error for a non-200 response, got none")
+	}
+}
+
+func TestStaticTokenAuth(t *testing.T) {
+	auth := &StaticTokenAuth{Token: "fixed-token"}
+
+	token, err := auth.Login()
+	if err != nil || token != "fixed-token" {
+		t.Fatalf("Login() = (%q, %v), want (fixed-token, nil)", token, err)
+	}
+
+	token, err = auth.Refresh()
+	if err != nil || token != "fixed-token" {
+		t.Fatalf("Refresh() = (%q, %v), want (fixed-token, nil)", token, err)
+	}
+}
+
+func TestNoAuth(t *testing.T) {
+	var auth NoAuth
+	if token, err := auth.Login(); token != "" || err != nil {
+		t.Fatalf("Login() = (%q, %v), want (\"\", nil)", token, err)
+	}
+	if token, err := auth.Refresh(); token != "" || err != nil {
+		t.Fatalf("Refresh() = (%q, %v), want (\"\", nil)", token, err)
+	}
+}
+
+// fakeAuthProvider is a scriptable AuthProvider used to observe how many
+// times sendRequest calls Refresh after a 401.
+type fakeAuthProvider struct {
+	refreshToken string
+	refreshErr   error
+	refreshCalls int
+}
+
+func (f *fakeAuthProvider) Login() (string, error) { return f.refreshToken, nil }
+
+func (f *fakeAuthProvider) Refresh() (string, error) {
+	f.refreshCalls++
+	return f.refreshToken, f.refreshErr
+}
+
+func TestSendRequestRefreshesTokenOnceAfter401(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Header.Get("Authorization") {
+		case "Bearer stale-token":
+			w.WriteHeader(http.StatusUnauthorized)
+		case "Bearer fresh-token":
+			w.WriteHeader(http.StatusOK)
+		default:
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	defer server.Close()
+
+	rt, err := buildRequestTemplate(Config{Method: "POST", EndpointURL: server.URL})
+	if err != nil {
+		t.Fatalf("buildRequestTemplate: %v", err)
+	}
+	auth := &fakeAuthProvider{refreshToken: "fresh-token"}
+	config := Config{
+		Logger:          NewLogger(LevelError),
+		RequestTemplate: rt,
+		AuthProvider:    auth,
+		Tokens:          NewTokenCache("stale-token"),
+	}
+
+	if reqErr := sendRequest(json.RawMessage(`{}`), config, 1, 1); reqErr != nil {
+		t.Fatalf("sendRequest: unexpected failure: %v", reqErr)
+	}
+	if auth.refreshCalls != 1 {
+		t.Errorf("Refresh calls = %d, want 1", auth.refreshCalls)
+	}
+	if got := config.Tokens.Get(); got != "fresh-token" {
+		t.Errorf("token cache = %q, want fresh-token", got)
+	}
+}
+
+func TestSendRequestGivesUpAfterOneFailedRefresh(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	rt, err := buildRequestTemplate(Config{Method: "POST", EndpointURL: server.URL})
+	if err != nil {
+		t.Fatalf("buildRequestTemplate: %v", err)
+	}
+	auth := &fakeAuthProvider{refreshToken: "still-bad-token"}
+	config := Config{
+		Logger:          NewLogger(LevelError),
+		RequestTemplate: rt,
+		AuthProvider:    auth,
+		Tokens:          NewTokenCache("stale-token"),
+	}
+
+	reqErr := sendRequest(json.RawMessage(`{}`), config, 1, 1)
+	if reqErr == nil || reqErr.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("sendRequest = %v, want a 401 RequestError", reqErr)
+	}
+	if auth.refreshCalls != 1 {
+		t.Errorf("Refresh calls = %d, want exactly 1 (retried once, not looped)", auth.refreshCalls)
+	}
+	if calls != 2 {
+		t.Errorf("server saw %d requests, want 2 (original + one retry)", calls)
+	}
+}
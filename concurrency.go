@@ -0,0 +1,117 @@
+package main
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// progressInterval is how often the in-flight count and effective RPS are
+// logged while requests are outstanding.
+const progressInterval = 2 * time.Second
+
+// okStreakWindow is how many consecutive clean responses the adaptive
+// concurrency controller waits for before raising the ceiling by one.
+const okStreakWindow = 20
+
+// ConcurrencyController bounds the number of in-flight requests, adapting
+// the effective limit with AIMD: a throttling response (429/503) halves it
+// immediately, while a sustained run of clean responses grows it back by one
+// at a time, up to the configured max.
+type ConcurrencyController struct {
+	mu        sync.Mutex
+	cond      *sync.Cond
+	active    int
+	limit     int
+	max       int
+	okStreak  int
+	completed int64
+}
+
+func NewConcurrencyController(max int) *ConcurrencyController {
+	c := &ConcurrencyController{limit: max, max: max}
+	c.cond = sync.NewCond(&c.mu)
+	return c
+}
+
+// Acquire blocks until a slot under the current limit is free.
+func (c *ConcurrencyController) Acquire() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for c.active >= c.limit {
+		c.cond.Wait()
+	}
+	c.active++
+}
+
+func (c *ConcurrencyController) Release() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.active--
+	c.cond.Broadcast()
+}
+
+// ReportResult feeds back whether the just-completed request was throttled,
+// adjusting the concurrency ceiling accordingly.
+func (c *ConcurrencyController) ReportResult(throttled bool) {
+	atomic.AddInt64(&c.completed, 1)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if throttled {
+		c.limit = max(1, c.limit/2)
+		c.okStreak = 0
+		c.cond.Broadcast()
+		return
+	}
+
+	c.okStreak++
+	if c.okStreak >= okStreakWindow && c.limit < c.max {
+		c.limit++
+		c.okStreak = 0
+		c.cond.Broadcast()
+	}
+}
+
+func (c *ConcurrencyController) InFlight() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.active
+}
+
+func (c *ConcurrencyController) Limit() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.limit
+}
+
+// swapCompleted returns the number of requests completed since the last
+// call and resets the counter.
+func (c *ConcurrencyController) swapCompleted() int64 {
+	return atomic.SwapInt64(&c.completed, 0)
+}
+
+// reportProgress logs the in-flight count, concurrency ceiling, and
+// effective requests/second at a fixed interval until the returned stop
+// function is called.
+func reportProgress(logger *Logger, controller *ConcurrencyController) (stop func()) {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(progressInterval)
+		defer ticker.Stop()
+		last := time.Now()
+		for {
+			select {
+			case now := <-ticker.C:
+				elapsed := now.Sub(last).Seconds()
+				rps := float64(controller.swapCompleted()) / elapsed
+				logger.Infof("progress: in_flight=%d concurrency_limit=%d effective_rps=%.1f", controller.InFlight(), controller.Limit(), rps)
+				last = now
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() { close(done) }
+}
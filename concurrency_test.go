@@ -0,0 +1,90 @@
+package main
+
+import "testing"
+
+func TestConcurrencyControllerThrottleHalvesLimit(t *testing.T) {
+	c := NewConcurrencyController(10)
+
+	c.ReportResult(true)
+	if got := c.Limit(); got != 5 {
+		t.Fatalf("Limit after one throttle = %d, want 5", got)
+	}
+
+	c.ReportResult(true)
+	if got := c.Limit(); got != 2 {
+		t.Fatalf("Limit after two throttles = %d, want 2", got)
+	}
+}
+
+func TestConcurrencyControllerThrottleFloorsAtOne(t *testing.T) {
+	c := NewConcurrencyController(1)
+	c.ReportResult(true)
+	if got := c.Limit(); got != 1 {
+		t.Fatalf("Limit = %d, want floor of 1", got)
+	}
+}
+
+func TestConcurrencyControllerRecoversGraduallyOnCleanStreak(t *testing.T) {
+	c := NewConcurrencyController(10)
+	c.ReportResult(true) // limit: 10 -> 5
+
+	for i := 0; i < okStreakWindow-1; i++ {
+		c.ReportResult(false)
+	}
+	if got := c.Limit(); got != 5 {
+		t.Fatalf("Limit before streak window elapses = %d, want still 5", got)
+	}
+
+	c.ReportResult(false) // completes the streak window
+	if got := c.Limit(); got != 6 {
+		t.Fatalf("Limit after a full clean streak = %d, want 6", got)
+	}
+}
+
+func TestConcurrencyControllerRecoveryCapsAtMax(t *testing.T) {
+	c := NewConcurrencyController(2)
+	c.ReportResult(true) // limit: 2 -> 1
+
+	for round := 0; round < 5; round++ {
+		for i := 0; i < okStreakWindow; i++ {
+			c.ReportResult(false)
+		}
+	}
+	if got := c.Limit(); got != 2 {
+		t.Fatalf("Limit after many clean streaks = %d, want capped at max 2", got)
+	}
+}
+
+func TestConcurrencyControllerThrottleResetsCleanStreak(t *testing.T) {
+	c := NewConcurrencyController(10)
+	c.ReportResult(true) // limit: 10 -> 5
+
+	for i := 0; i < okStreakWindow-1; i++ {
+		c.ReportResult(false)
+	}
+	c.ReportResult(true) // throttled again before the streak completes
+	if got := c.Limit(); got != 2 {
+		t.Fatalf("Limit after throttle mid-streak = %d, want 2", got)
+	}
+
+	for i := 0; i < okStreakWindow-1; i++ {
+		c.ReportResult(false)
+	}
+	if got := c.Limit(); got != 2 {
+		t.Fatalf("Limit = %d, want still 2 since the streak was reset by the throttle", got)
+	}
+}
+
+func TestConcurrencyControllerAcquireRelease(t *testing.T) {
+	c := NewConcurrencyController(2)
+	c.Acquire()
+	c.Acquire()
+	if got := c.InFlight(); got != 2 {
+		t.Fatalf("InFlight after two acquires = %d, want 2", got)
+	}
+
+	c.Release()
+	if got := c.InFlight(); got != 1 {
+		t.Fatalf("InFlight after one release = %d, want 1", got)
+	}
+}
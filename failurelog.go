@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// FailureRecord is one NDJSON line in a failure log: the original item plus
+// enough detail to understand why it failed without reprocessing the input.
+type FailureRecord struct {
+	Item       json.RawMessage `json:"item"`
+	StatusCode int             `json:"status_code"`
+	Error      string          `json:"error"`
+	Attempts   int             `json:"attempts"`
+}
+
+// FailureLog appends FailureRecords as NDJSON so failed items can later be
+// replayed via --resume-from. A nil *FailureLog is valid and a no-op, so
+// callers don't need to branch on whether --failure-log was set.
+type FailureLog struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+func OpenFailureLog(path string) (*FailureLog, error) {
+	if path == "" {
+		return nil, nil
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening failure log: %w", err)
+	}
+	return &FailureLog{file: file}, nil
+}
+
+func (fl *FailureLog) Record(item json.RawMessage, reqErr *RequestError, attempts int) error {
+	if fl == nil {
+		return nil
+	}
+
+	rec := FailureRecord{
+		Item:       item,
+		StatusCode: reqErr.StatusCode,
+		Error:      reqErr.Error(),
+		Attempts:   attempts,
+	}
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("error marshaling failure record: %w", err)
+	}
+	data = append(data, '\n')
+
+	fl.mu.Lock()
+	defer fl.mu.Unlock()
+	_, err = fl.file.Write(data)
+	return err
+}
+
+func (fl *FailureLog) Close() error {
+	if fl == nil {
+		return nil
+	}
+	return fl.file.Close()
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// itemSource yields the JSON items to send, one at a time. Next returns
+// io.EOF once exhausted.
+type itemSource interface {
+	Next() (json.RawMessage, error)
+	Close() error
+}
+
+// arrayItemSource reads items from a top-level JSON array, the tool's
+// original input format.
+type arrayItemSource struct {
+	file    *os.File
+	decoder *json.Decoder
+}
+
+func newArrayItemSource(path string) (*arrayItemSource, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening file: %w", err)
+	}
+
+	decoder := json.NewDecoder(file)
+	if _, err := decoder.Token(); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("error reading opening bracket: %w", err)
+	}
+
+	return &arrayItemSource{file: file, decoder: decoder}, nil
+}
+
+func (s *arrayItemSource) Next() (json.RawMessage, error) {
+	if !s.decoder.More() {
+		return nil, io.EOF
+	}
+	var item json.RawMessage
+	if err := s.decoder.Decode(&item); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+func (s *arrayItemSource) Close() error {
+	return s.file.Close()
+}
+
+// ndjsonItemSource reads items from a failure log written by FailureLog,
+// one FailureRecord per line, so a previous run's failures can be replayed.
+type ndjsonItemSource struct {
+	file    *os.File
+	scanner *bufio.Scanner
+}
+
+func newNDJSONItemSource(path string) (*ndjsonItemSource, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening file: %w", err)
+	}
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+
+	return &ndjsonItemSource{file: file, scanner: scanner}, nil
+}
+
+func (s *ndjsonItemSource) Next() (json.RawMessage, error) {
+	for s.scanner.Scan() {
+		line := s.scanner.Bytes()
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		var rec FailureRecord
+		if err := json.Unmarshal(line, &rec); err != nil {
+			return nil, fmt.Errorf("error decoding failure record: %w", err)
+		}
+		return rec.Item, nil
+	}
+	if err := s.scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+func (s *ndjsonItemSource) Close() error {
+	return s.file.Close()
+}
+
+// openItemSource picks the array or NDJSON source based on config: a
+// --resume-from path replays a previous failure log instead of the primary
+// input file.
+func openItemSource(config Config) (itemSource, error) {
+	if config.ResumeFrom != "" {
+		return newNDJSONItemSource(config.ResumeFrom)
+	}
+	return newArrayItemSource(config.InputFile)
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestArrayItemSource(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "items.json")
+	if err := os.WriteFile(path, []byte(`[{"id":1},{"id":2}]`), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	source, err := newArrayItemSource(path)
+	if err != nil {
+		t.Fatalf("newArrayItemSource: %v", err)
+	}
+	defer source.Close()
+
+	var got []string
+	for {
+		item, err := source.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Next: %v", err)
+		}
+		got = append(got, string(item))
+	}
+
+	want := []string{`{"id":1}`, `{"id":2}`}
+	if len(got) != len(want) {
+		t.Fatalf("got %d items, want %d: %v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("item %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestNDJSONItemSourceRoundTripsFailureLog(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "failures.ndjson")
+
+	failureLog, err := OpenFailureLog(path)
+	if err != nil {
+		t.Fatalf("OpenFailureLog: %v", err)
+	}
+	items := []string{`{"id":1}`, `{"id":2}`}
+	for _, item := range items {
+		if err := failureLog.Record(json.RawMessage(item), &RequestError{StatusCode: 500}, 3); err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+	}
+	if err := failureLog.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	source, err := newNDJSONItemSource(path)
+	if err != nil {
+		t.Fatalf("newNDJSONItemSource: %v", err)
+	}
+	defer source.Close()
+
+	for i, want := range items {
+		item, err := source.Next()
+		if err != nil {
+			t.Fatalf("Next(%d): %v", i, err)
+		}
+		if string(item) != want {
+			t.Errorf("item %d = %q, want %q", i, item, want)
+		}
+	}
+	if _, err := source.Next(); err != io.EOF {
+		t.Fatalf("Next after last item = %v, want io.EOF", err)
+	}
+}
+
+func TestValidateResumeFailureLogPaths(t *testing.T) {
+	dir := t.TempDir()
+	samePath := filepath.Join(dir, "failures.ndjson")
+	relative := "./" + filepath.Base(samePath)
+
+	tests := []struct {
+		name       string
+		resumeFrom string
+		failureLog string
+		wantErr    bool
+	}{
+		{name: "neither flag set", resumeFrom: "", failureLog: ""},
+		{name: "only resume-from set", resumeFrom: samePath, failureLog: ""},
+		{name: "only failure-log set", resumeFrom: "", failureLog: samePath},
+		{name: "distinct paths", resumeFrom: filepath.Join(dir, "a.ndjson"), failureLog: filepath.Join(dir, "b.ndjson")},
+		{name: "identical path", resumeFrom: samePath, failureLog: samePath, wantErr: true},
+		{name: "same file via relative path", resumeFrom: samePath, failureLog: relative, wantErr: true},
+	}
+
+	oldwd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Getwd: %v", err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("Chdir: %v", err)
+	}
+	defer os.Chdir(oldwd)
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateResumeFailureLogPaths(tc.resumeFrom, tc.failureLog)
+			if tc.wantErr && err == nil {
+				t.Fatalf("expected an error, got none")
+			}
+			if !tc.wantErr && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"log"
+	"os"
+)
+
+// Level controls which log lines a Logger emits.
+type Level int
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+// Logger is a minimal leveled logger writing to stderr, keeping stdout free
+// for the tool's own structured per-request output so both can be piped
+// independently in CI.
+type Logger struct {
+	level Level
+	out   *log.Logger
+}
+
+func NewLogger(level Level) *Logger {
+	return &Logger{
+		level: level,
+		out:   log.New(os.Stderr, "", log.LstdFlags),
+	}
+}
+
+func (l *Logger) Debugf(format string, args ...interface{}) {
+	if l.level <= LevelDebug {
+		l.out.Printf("DEBUG "+format, args...)
+	}
+}
+
+func (l *Logger) Infof(format string, args ...interface{}) {
+	if l.level <= LevelInfo {
+		l.out.Printf("INFO "+format, args...)
+	}
+}
+
+func (l *Logger) Warnf(format string, args ...interface{}) {
+	if l.level <= LevelWarn {
+		l.out.Printf("WARN "+format, args...)
+	}
+}
+
+func (l *Logger) Errorf(format string, args ...interface{}) {
+	if l.level <= LevelError {
+		l.out.Printf("ERROR "+format, args...)
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"testing"
+)
+
+func TestLoggerLevelFiltering(t *testing.T) {
+	tests := []struct {
+		name       string
+		level      Level
+		wantPrefix []string // prefixes expected to appear
+		wantAbsent []string // prefixes expected to be suppressed
+	}{
+		{
+			name:       "debug level emits everything",
+			level:      LevelDebug,
+			wantPrefix: []string{"DEBUG d", "INFO i", "WARN w", "ERROR e"},
+		},
+		{
+			name:       "info level suppresses debug",
+			level:      LevelInfo,
+			wantPrefix: []string{"INFO i", "WARN w", "ERROR e"},
+			wantAbsent: []string{"DEBUG d"},
+		},
+		{
+			name:       "warn level suppresses debug and info",
+			level:      LevelWarn,
+			wantPrefix: []string{"WARN w", "ERROR e"},
+			wantAbsent: []string{"DEBUG d", "INFO i"},
+		},
+		{
+			name:       "error level only emits error",
+			level:      LevelError,
+			wantPrefix: []string{"ERROR e"},
+			wantAbsent: []string{"DEBUG d", "INFO i", "WARN w"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := &Logger{level: tc.level, out: log.New(&buf, "", 0)}
+
+			logger.Debugf("d%d", 1)
+			logger.Infof("i%d", 2)
+			logger.Warnf("w%d", 3)
+			logger.Errorf("e%d", 4)
+
+			got := buf.String()
+			for _, want := range tc.wantPrefix {
+				if !strings.Contains(got, want) {
+					t.Errorf("output missing %q, got:\n%s", want, got)
+				}
+			}
+			for _, absent := range tc.wantAbsent {
+				if strings.Contains(got, absent) {
+					t.Errorf("output should not contain %q, got:\n%s", absent, got)
+				}
+			}
+		})
+	}
+}
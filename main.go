@@ -2,30 +2,100 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
+	"math/rand"
+	"net"
 	"net/http"
+	"net/http/httputil"
 	"net/url"
 	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
+
+	"golang.org/x/time/rate"
 )
 
+// redactedAuthorizationValue replaces a real Authorization header wherever
+// a request is written somewhere other than over the wire (dry-run output,
+// --record files), so a bearer token never ends up on disk or a terminal.
+const redactedAuthorizationValue = "Bearer [REDACTED]"
+
 type Config struct {
-	Method      string
-	Email       string
-	Password    string
-	EndpointURL string
-	InputFile   string
+	Method            string
+	Email             string
+	Password          string
+	EndpointURL       string
+	InputFile         string
+	MaxRetries        int
+	RetryBaseDelay    time.Duration
+	RetryMaxDelay     time.Duration
+	Logger            *Logger
+	FailureLogPath    string
+	ResumeFrom        string
+	Token             string
+	OAuthClientID     string
+	OAuthClientSecret string
+	OAuthTokenURL     string
+	AuthProvider      AuthProvider
+	Tokens            *TokenCache
+	Concurrency       int
+	RateLimit         float64
+	Headers           headerFlags
+	BodyTemplateFile  string
+	RequestTemplate   *RequestTemplate
+	DryRun            bool
+	RecordDir         string
+	ReplayDir         string
+	Recorder          *Recorder
+	Replayer          *Replayer
 }
 
 type LoginResponse struct {
 	Token string `json:"token"`
 }
 
+// RequestError describes a failed send, classifying whether it came from
+// the network or from the server so callers can decide whether to retry.
+type RequestError struct {
+	StatusCode int    // 0 for network-level failures (no response received)
+	Body       []byte // response body, if any
+	RetryAfter time.Duration
+	Err        error
+}
+
+func (e *RequestError) Error() string {
+	if e.StatusCode == 0 {
+		return fmt.Sprintf("network error: %v", e.Err)
+	}
+	return fmt.Sprintf("request failed with status %d: %s", e.StatusCode, bytes.TrimSpace(e.Body))
+}
+
+// Retryable reports whether attempting the request again is worthwhile:
+// network errors and 5xx responses are retried, as are 408/429, while the
+// remaining 4xx responses are treated as permanent failures.
+func (e *RequestError) Retryable() bool {
+	switch {
+	case e.StatusCode == 0:
+		return true
+	case e.StatusCode == http.StatusRequestTimeout, e.StatusCode == http.StatusTooManyRequests:
+		return true
+	case e.StatusCode >= 500:
+		return true
+	default:
+		return false
+	}
+}
+
 func main() {
 	config, err := parseFlags()
 	if err != nil {
@@ -34,56 +104,305 @@ func main() {
 		os.Exit(1)
 	}
 
-	bearerToken, err := login(config.Email, config.Password, config.EndpointURL)
+	var token string
+	if config.ReplayDir == "" {
+		token, err = config.AuthProvider.Login()
+		if err != nil {
+			config.Logger.Errorf("logging in: %v", err)
+			return
+		}
+	}
+	config.Tokens = NewTokenCache(token)
+
+	source, err := openItemSource(config)
 	if err != nil {
-		fmt.Printf("Error logging in: %v\n", err)
+		config.Logger.Errorf("opening input: %v", err)
 		return
 	}
+	defer source.Close()
 
-	file, err := os.Open(config.InputFile)
+	failureLog, err := OpenFailureLog(config.FailureLogPath)
 	if err != nil {
-		fmt.Printf("Error opening file: %v\n", err)
+		config.Logger.Errorf("opening failure log: %v", err)
 		return
 	}
-	defer file.Close()
-
-	decoder := json.NewDecoder(file)
+	defer failureLog.Close()
 
-	_, err = decoder.Token()
+	recorder, err := NewRecorder(config.RecordDir)
 	if err != nil {
-		fmt.Printf("Error reading opening bracket: %v\n", err)
+		config.Logger.Errorf("opening record directory: %v", err)
 		return
 	}
+	config.Recorder = recorder
 
-	var wg sync.WaitGroup
-	semaphore := make(chan struct{}, 10)
-	var successCount, failCount int64
+	if config.ReplayDir != "" {
+		config.Replayer = NewReplayer(config.ReplayDir)
+	}
+
+	controller := NewConcurrencyController(config.Concurrency)
+	var limiter *rate.Limiter
+	if config.RateLimit > 0 {
+		limiter = rate.NewLimiter(rate.Limit(config.RateLimit), int(config.Concurrency))
+	}
 
-	for decoder.More() {
-		var item json.RawMessage
-		if err := decoder.Decode(&item); err != nil {
-			fmt.Printf("Error decoding item: %v\n", err)
+	stopProgress := reportProgress(config.Logger, controller)
+	defer stopProgress()
+
+	var wg sync.WaitGroup
+	var successCount int64
+	var failuresMu sync.Mutex
+	var failures []*RequestError
+	index := 0
+
+	for {
+		item, err := source.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			config.Logger.Errorf("decoding item: %v", err)
 			continue
 		}
+		index++
+
+		controller.Acquire()
+		if limiter != nil {
+			limiter.Wait(context.Background())
+		}
 
 		wg.Add(1)
-		semaphore <- struct{}{}
-		go func(data json.RawMessage) {
+		go func(data json.RawMessage, index int) {
 			defer wg.Done()
-			defer func() { <-semaphore }()
-			if sendRequest(data, bearerToken, config) {
-				atomic.AddInt64(&successCount, 1)
+			defer controller.Release()
+
+			reqErr, attempts := sendWithRetry(data, config, index)
+			controller.ReportResult(isThrottled(reqErr))
+			if reqErr != nil {
+				failureLog.Record(data, reqErr, attempts)
+				failuresMu.Lock()
+				failures = append(failures, reqErr)
+				failuresMu.Unlock()
 			} else {
-				atomic.AddInt64(&failCount, 1)
+				atomic.AddInt64(&successCount, 1)
 			}
-		}(item)
+		}(item, index)
 	}
 
 	wg.Wait()
 
+	printSummary(successCount, failures)
+}
+
+func printSummary(successCount int64, failures []*RequestError) {
 	fmt.Printf("\nExecution Summary:\n")
 	fmt.Printf("Successful requests: %d\n", successCount)
-	fmt.Printf("Failed requests: %d\n", failCount)
+	fmt.Printf("Failed requests: %d\n", len(failures))
+
+	if len(failures) == 0 {
+		return
+	}
+
+	counts := make(map[string]int64)
+	for _, f := range failures {
+		counts[classifyError(f)]++
+	}
+
+	classes := make([]string, 0, len(counts))
+	for class := range counts {
+		classes = append(classes, class)
+	}
+	sort.Strings(classes)
+
+	fmt.Printf("Failures by class:\n")
+	for _, class := range classes {
+		fmt.Printf("  %s: %d\n", class, counts[class])
+	}
+}
+
+// classifyError buckets a RequestError for the summary: network errors group
+// by the underlying cause (DNS failure, timeout, connection error, ...),
+// HTTP errors group by status code.
+func classifyError(e *RequestError) string {
+	if e.StatusCode == 0 {
+		return fmt.Sprintf("network (%s)", networkErrorClass(e.Err))
+	}
+	return fmt.Sprintf("http %d", e.StatusCode)
+}
+
+// networkErrorClass unwraps a network error down to a cause worth grouping
+// by. Every network error reaching here has been wrapped at least once with
+// fmt.Errorf("...: %w", err) on its way out of doSend, so %T on the error
+// itself always resolves to *fmt.wrapError; errors.As walks the chain to
+// find the actual net.DNSError/net.OpError/net.Error underneath.
+func networkErrorClass(err error) string {
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return "dns"
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return "timeout"
+	}
+
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		return fmt.Sprintf("%s: %s", opErr.Op, rootCause(opErr))
+	}
+
+	return rootCause(err).Error()
+}
+
+// rootCause follows an error's Unwrap chain to its innermost cause.
+func rootCause(err error) error {
+	for {
+		unwrapped := errors.Unwrap(err)
+		if unwrapped == nil {
+			return err
+		}
+		err = unwrapped
+	}
+}
+
+// isThrottled reports whether a failure indicates the server wants us to
+// back off, the signal the adaptive concurrency controller reacts to.
+func isThrottled(reqErr *RequestError) bool {
+	return reqErr != nil && (reqErr.StatusCode == http.StatusTooManyRequests || reqErr.StatusCode == http.StatusServiceUnavailable)
+}
+
+// sendWithRetry calls sendRequest, retrying retryable failures with
+// exponential backoff and full jitter until config.MaxRetries is exhausted.
+// A --dry-run or --replay configuration bypasses retries entirely: neither
+// touches the network, so there's nothing to retry.
+func sendWithRetry(data json.RawMessage, config Config, index int) (*RequestError, int) {
+	if config.DryRun {
+		if err := printDryRun(data, config, index); err != nil {
+			config.Logger.Errorf("dry-run: %v", err)
+			return &RequestError{Err: err}, 1
+		}
+		return nil, 1
+	}
+	if config.Replayer != nil {
+		return replayItem(data, config, index), 1
+	}
+
+	var lastErr *RequestError
+
+	for attempt := 0; attempt <= config.MaxRetries; attempt++ {
+		reqErr := sendRequest(data, config, attempt+1, index)
+		if reqErr == nil {
+			return nil, attempt + 1
+		}
+
+		lastErr = reqErr
+		if attempt == config.MaxRetries || !reqErr.Retryable() {
+			return lastErr, attempt + 1
+		}
+
+		time.Sleep(backoffDelay(config, attempt, reqErr.RetryAfter))
+	}
+
+	return lastErr, config.MaxRetries + 1
+}
+
+// dryRunOutputMu serializes writes to stdout across the worker goroutines
+// that call printDryRun concurrently, so one item's lines can't interleave
+// with another's.
+var dryRunOutputMu sync.Mutex
+
+// printDryRun renders the request that would be sent and prints it without
+// performing auth refresh or any network call, redacting the bearer token.
+// It returns an error instead of logging one itself so a failed render is
+// counted as a failure by the caller rather than as a silent success.
+func printDryRun(data json.RawMessage, config Config, index int) error {
+	method, targetURL, headers, body, err := config.RequestTemplate.Render(data)
+	if err != nil {
+		return fmt.Errorf("rendering item %d: %w", index, err)
+	}
+
+	if config.Tokens.Get() != "" {
+		headers["Authorization"] = redactedAuthorizationValue
+	}
+
+	keys := make([]string, 0, len(headers))
+	for key := range headers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "DRY RUN #%05d %s %s\n", index, method, targetURL)
+	for _, key := range keys {
+		fmt.Fprintf(&out, "  %s: %s\n", key, headers[key])
+	}
+	fmt.Fprintf(&out, "%s\n\n", body)
+
+	dryRunOutputMu.Lock()
+	defer dryRunOutputMu.Unlock()
+	fmt.Print(out.String())
+	return nil
+}
+
+// replayItem simulates a send using a response previously written by
+// --record, so downstream summary/reporting logic can be exercised without
+// the network.
+func replayItem(data json.RawMessage, config Config, index int) *RequestError {
+	resp, err := config.Replayer.Response(index)
+	if err != nil {
+		return &RequestError{Err: err}
+	}
+
+	config.Logger.Infof("method=REPLAY status=%d attempt=1 index=%d", resp.StatusCode, index)
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	return &RequestError{StatusCode: resp.StatusCode, Body: resp.Body}
+}
+
+// backoffDelay computes the next retry delay: exponential backoff with full
+// jitter (sleep = rand(0, min(maxDelay, base*2^attempt))), overridden by a
+// server-supplied Retry-After when present.
+func backoffDelay(config Config, attempt int, retryAfter time.Duration) time.Duration {
+	if retryAfter > 0 {
+		return retryAfter
+	}
+
+	upper := config.RetryBaseDelay * time.Duration(1<<uint(attempt))
+	if upper <= 0 || upper > config.RetryMaxDelay {
+		upper = config.RetryMaxDelay
+	}
+	if upper <= 0 {
+		return 0
+	}
+
+	return time.Duration(rand.Int63n(int64(upper) + 1))
+}
+
+// validateResumeFailureLogPaths rejects a --resume-from/--failure-log pair
+// that name the same file. OpenFailureLog truncates its path immediately at
+// startup, while the NDJSON scanner behind --resume-from only reads its
+// file lazily on the first source.Next() call in the main loop, so pointing
+// both at the same "retry until clean" file would silently wipe it before a
+// single item was read.
+func validateResumeFailureLogPaths(resumeFrom, failureLogPath string) error {
+	if resumeFrom == "" || failureLogPath == "" {
+		return nil
+	}
+
+	resumeAbs, err := filepath.Abs(resumeFrom)
+	if err != nil {
+		return fmt.Errorf("error resolving -resume-from path: %w", err)
+	}
+	failureAbs, err := filepath.Abs(failureLogPath)
+	if err != nil {
+		return fmt.Errorf("error resolving -failure-log path: %w", err)
+	}
+
+	if resumeAbs == failureAbs {
+		return fmt.Errorf("-resume-from and -failure-log must not be the same path: %s", resumeAbs)
+	}
+	return nil
 }
 
 func parseFlags() (Config, error) {
@@ -92,38 +411,71 @@ func parseFlags() (Config, error) {
 	password := flag.String("password", "", "Password for login")
 	endpointURL := flag.String("url", "", "Endpoint URL")
 	inputFile := flag.String("input", "", "Path to the JSON input file")
+	maxRetries := flag.Int("max-retries", 3, "Maximum number of retry attempts per item")
+	retryBaseDelay := flag.Duration("retry-base-delay", 500*time.Millisecond, "Base delay for exponential backoff between retries")
+	retryMaxDelay := flag.Duration("retry-max-delay", 30*time.Second, "Maximum delay between retries")
+	verbose := flag.Bool("verbose", false, "Log full request/response wire content for each item")
+	debug := flag.Bool("debug", false, "Alias for -verbose")
+	failureLogPath := flag.String("failure-log", "", "Path to write an NDJSON log of failed items")
+	resumeFrom := flag.String("resume-from", "", "Read items from a previous --failure-log instead of --input")
+	token := flag.String("token", os.Getenv("AUTH_TOKEN"), "Static bearer token to use instead of email/password login ($AUTH_TOKEN)")
+	oauthClientID := flag.String("oauth-client-id", "", "OAuth2 client id for the client_credentials grant")
+	oauthClientSecret := flag.String("oauth-client-secret", "", "OAuth2 client secret for the client_credentials grant")
+	oauthTokenURL := flag.String("oauth-token-url", "", "OAuth2 token endpoint for the client_credentials grant")
+	concurrency := flag.Int("concurrency", 10, "Maximum number of in-flight requests")
+	rateLimit := flag.Float64("rate-limit", 0, "Maximum requests per second (0 = unlimited)")
+	var headers headerFlags
+	flag.Var(&headers, "header", "Repeatable key=tmpl header, evaluated as a Go template against each item")
+	bodyTemplateFile := flag.String("body-template", "", "Path to a Go template file used to build each item's request body")
+	dryRun := flag.Bool("dry-run", false, "Print each fully-constructed request instead of sending it")
+	recordDir := flag.String("record", "", "Directory to write each request/response pair to, as numbered files")
+	replayDir := flag.String("replay", "", "Directory of a previous --record run to replay instead of sending over the network")
 
 	flag.Parse()
 
+	level := LevelInfo
+	if *verbose || *debug {
+		level = LevelDebug
+	}
+
 	config := Config{
-		Method:      *method,
-		Email:       *email,
-		Password:    *password,
-		EndpointURL: *endpointURL,
-		InputFile:   *inputFile,
+		Method:            *method,
+		Email:             *email,
+		Password:          *password,
+		EndpointURL:       *endpointURL,
+		InputFile:         *inputFile,
+		MaxRetries:        *maxRetries,
+		RetryBaseDelay:    *retryBaseDelay,
+		RetryMaxDelay:     *retryMaxDelay,
+		Logger:            NewLogger(level),
+		FailureLogPath:    *failureLogPath,
+		ResumeFrom:        *resumeFrom,
+		Token:             *token,
+		OAuthClientID:     *oauthClientID,
+		OAuthClientSecret: *oauthClientSecret,
+		OAuthTokenURL:     *oauthTokenURL,
+		Concurrency:       *concurrency,
+		RateLimit:         *rateLimit,
+		Headers:           headers,
+		BodyTemplateFile:  *bodyTemplateFile,
+		DryRun:            *dryRun,
+		RecordDir:         *recordDir,
+		ReplayDir:         *replayDir,
 	}
 
 	var missingParams []string
 
 	if config.Method == "" {
 		missingParams = append(missingParams, "method")
-	} else if config.Method != "POST" && config.Method != "PUT" {
-		return Config{}, fmt.Errorf("invalid method: %s. Must be POST or PUT", config.Method)
-	}
-
-	if config.Email == "" {
-		missingParams = append(missingParams, "email")
-	}
-
-	if config.Password == "" {
-		missingParams = append(missingParams, "password")
+	} else if !strings.Contains(config.Method, "{{") && config.Method != "POST" && config.Method != "PUT" {
+		return Config{}, fmt.Errorf("invalid method: %s. Must be POST or PUT, or a template", config.Method)
 	}
 
 	if config.EndpointURL == "" {
 		missingParams = append(missingParams, "url")
 	}
 
-	if config.InputFile == "" {
+	if config.InputFile == "" && config.ResumeFrom == "" {
 		missingParams = append(missingParams, "input")
 	}
 
@@ -131,6 +483,30 @@ func parseFlags() (Config, error) {
 		return Config{}, fmt.Errorf("missing required parameters: %s", strings.Join(missingParams, ", "))
 	}
 
+	if config.MaxRetries < 0 {
+		return Config{}, fmt.Errorf("max-retries must be non-negative")
+	}
+
+	if config.Concurrency < 1 {
+		return Config{}, fmt.Errorf("concurrency must be at least 1")
+	}
+
+	if err := validateResumeFailureLogPaths(config.ResumeFrom, config.FailureLogPath); err != nil {
+		return Config{}, err
+	}
+
+	authProvider, err := selectAuthProvider(config)
+	if err != nil {
+		return Config{}, err
+	}
+	config.AuthProvider = authProvider
+
+	requestTemplate, err := buildRequestTemplate(config)
+	if err != nil {
+		return Config{}, err
+	}
+	config.RequestTemplate = requestTemplate
+
 	return config, nil
 }
 
@@ -170,32 +546,115 @@ func login(email, password string, endpointURL string) (string, error) {
 	return loginResp.Token, nil
 }
 
-func sendRequest(data json.RawMessage, bearerToken string, config Config) bool {
+// sendRequest issues a single attempt and returns a *RequestError describing
+// why it failed, or nil on a 2xx response. On a 401 it asks config.AuthProvider
+// for a fresh token, updates the shared TokenCache, and retries once before
+// giving up.
+func sendRequest(data json.RawMessage, config Config, attempt int, index int) *RequestError {
+	reqErr := doSend(data, config.Tokens.Get(), config, attempt, index)
+	if reqErr == nil || reqErr.StatusCode != http.StatusUnauthorized {
+		return reqErr
+	}
+
+	newToken, err := config.AuthProvider.Refresh()
+	if err != nil {
+		config.Logger.Warnf("refreshing auth token after 401: %v", err)
+		return reqErr
+	}
+	config.Tokens.Set(newToken)
+
+	return doSend(data, newToken, config, attempt, index)
+}
+
+// redactAuthorization clones headers with any Authorization value replaced,
+// so a --record file on disk can't leak the real bearer token the same way
+// --dry-run already avoids printing it.
+func redactAuthorization(headers http.Header) http.Header {
+	redacted := headers.Clone()
+	if redacted.Get("Authorization") != "" {
+		redacted.Set("Authorization", redactedAuthorizationValue)
+	}
+	return redacted
+}
+
+// doSend performs a single wire-level request with the given bearer token.
+// It always emits one structured summary line per attempt, and in debug
+// mode additionally dumps the full wire-level request and response. When
+// --record is set, it also saves the request/response pair to disk.
+func doSend(data json.RawMessage, bearerToken string, config Config, attempt int, index int) *RequestError {
+	method, targetURL, headers, body, err := config.RequestTemplate.Render(data)
+	if err != nil {
+		return &RequestError{Err: err}
+	}
+
 	client := &http.Client{}
-	req, err := http.NewRequest(config.Method, config.EndpointURL, bytes.NewReader(data))
+	req, err := http.NewRequest(method, targetURL, bytes.NewReader(body))
 	if err != nil {
-		fmt.Printf("Error creating request: %v\n", err)
-		return false
+		return &RequestError{Err: fmt.Errorf("error creating request: %w", err)}
 	}
 
 	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
 	if bearerToken != "" {
 		req.Header.Set("Authorization", "Bearer "+bearerToken)
 	}
 
+	if dump, dumpErr := httputil.DumpRequestOut(req, true); dumpErr == nil {
+		config.Logger.Debugf("request (attempt %d):\n%s", attempt, dump)
+	}
+
+	start := time.Now()
 	resp, err := client.Do(req)
+	duration := time.Since(start)
 	if err != nil {
-		fmt.Printf("Error sending request: %v\n", err)
-		return false
+		config.Logger.Infof("method=%s url=%s status=ERR duration=%s attempt=%d error=%v", method, targetURL, duration, attempt, err)
+		return &RequestError{Err: fmt.Errorf("error sending request: %w", err)}
 	}
 	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
+	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		fmt.Printf("Error reading response: %v\n", err)
-		return false
+		return &RequestError{Err: fmt.Errorf("error reading response: %w", err)}
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	if dump, dumpErr := httputil.DumpResponse(resp, true); dumpErr == nil {
+		config.Logger.Debugf("response (attempt %d):\n%s", attempt, dump)
 	}
 
-	fmt.Printf("Response: %s\n", body)
-	return resp.StatusCode >= 200 && resp.StatusCode < 300
+	config.Logger.Infof("method=%s url=%s status=%d duration=%s bytes=%d attempt=%d", method, targetURL, resp.StatusCode, duration, len(respBody), attempt)
+
+	if err := config.Recorder.Record(index,
+		RecordedRequest{Method: method, URL: targetURL, Headers: redactAuthorization(req.Header), Body: body},
+		RecordedResponse{StatusCode: resp.StatusCode, Headers: resp.Header, Body: respBody},
+	); err != nil {
+		config.Logger.Warnf("recording item %d: %v", index, err)
+	}
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	reqErr := &RequestError{StatusCode: resp.StatusCode, Body: respBody}
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable {
+		reqErr.RetryAfter = parseRetryAfter(resp.Header.Get("Retry-After"))
+	}
+	return reqErr
+}
+
+// parseRetryAfter parses a Retry-After header, which per RFC 7231 is either
+// an integer number of seconds or an HTTP-date. Unparseable values return 0.
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if when, err := http.ParseTime(value); err == nil {
+		return time.Until(when)
+	}
+	return 0
 }
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestRedactAuthorization(t *testing.T) {
+	headers := http.Header{
+		"Authorization": []string{"Bearer super-secret-token"},
+		"Content-Type":  []string{"application/json"},
+	}
+
+	redacted := redactAuthorization(headers)
+
+	if got := redacted.Get("Authorization"); got != redactedAuthorizationValue {
+		t.Errorf("redacted Authorization = %q, want %q", got, redactedAuthorizationValue)
+	}
+	if got := redacted.Get("Content-Type"); got != "application/json" {
+		t.Errorf("redacted Content-Type = %q, want unchanged", got)
+	}
+	if got := headers.Get("Authorization"); got != "Bearer super-secret-token" {
+		t.Errorf("original headers mutated: Authorization = %q", got)
+	}
+}
+
+func TestRedactAuthorizationNoAuthHeader(t *testing.T) {
+	headers := http.Header{"Content-Type": []string{"application/json"}}
+	redacted := redactAuthorization(headers)
+	if got := redacted.Get("Authorization"); got != "" {
+		t.Errorf("redacted Authorization = %q, want empty when none was set", got)
+	}
+}
+
+func TestBackoffDelay(t *testing.T) {
+	config := Config{RetryBaseDelay: 500 * time.Millisecond, RetryMaxDelay: 30 * time.Second}
+
+	tests := []struct {
+		name       string
+		attempt    int
+		retryAfter time.Duration
+		wantMax    time.Duration
+	}{
+		{name: "retry-after overrides backoff", attempt: 0, retryAfter: 10 * time.Second, wantMax: 10 * time.Second},
+		{name: "attempt 0 caps at base*2^0", attempt: 0, wantMax: config.RetryBaseDelay},
+		{name: "attempt 3 caps at base*2^3", attempt: 3, wantMax: config.RetryBaseDelay * 8},
+		{name: "large attempt caps at retry-max-delay", attempt: 20, wantMax: config.RetryMaxDelay},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			for i := 0; i < 20; i++ {
+				got := backoffDelay(config, tc.attempt, tc.retryAfter)
+				if got < 0 || got > tc.wantMax {
+					t.Fatalf("backoffDelay(attempt=%d, retryAfter=%v) = %v, want in [0, %v]", tc.attempt, tc.retryAfter, got, tc.wantMax)
+				}
+			}
+		})
+	}
+}
+
+func TestBackoffDelayZeroMaxDelay(t *testing.T) {
+	config := Config{RetryBaseDelay: 500 * time.Millisecond, RetryMaxDelay: 0}
+	if got := backoffDelay(config, 0, 0); got != 0 {
+		t.Errorf("backoffDelay with zero RetryMaxDelay = %v, want 0", got)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  time.Duration
+	}{
+		{name: "empty", value: "", want: 0},
+		{name: "seconds", value: "120", want: 120 * time.Second},
+		{name: "unparseable", value: "not-a-duration", want: 0},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := parseRetryAfter(tc.value); got != tc.want {
+				t.Errorf("parseRetryAfter(%q) = %v, want %v", tc.value, got, tc.want)
+			}
+		})
+	}
+
+	t.Run("http-date", func(t *testing.T) {
+		when := time.Now().Add(90 * time.Second)
+		got := parseRetryAfter(when.UTC().Format(http.TimeFormat))
+		if got <= 0 || got > 90*time.Second {
+			t.Errorf("parseRetryAfter(http-date) = %v, want roughly 90s", got)
+		}
+	})
+}
+
+// fakeTimeoutErr is a minimal net.Error whose Timeout() reports true, used
+// to exercise networkErrorClass's timeout branch without a real dial.
+type fakeTimeoutErr struct{}
+
+func (fakeTimeoutErr) Error() string   { return "i/o timeout" }
+func (fakeTimeoutErr) Timeout() bool   { return true }
+func (fakeTimeoutErr) Temporary() bool { return true }
+
+func TestNetworkErrorClass(t *testing.T) {
+	dnsErr := &net.DNSError{Err: "no such host", Name: "example.invalid"}
+	timeoutErr := &net.OpError{Op: "dial", Net: "tcp", Err: fakeTimeoutErr{}}
+	refusedErr := &net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}
+
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{name: "dns lookup failure", err: fmt.Errorf("error sending request: %w", dnsErr), want: "dns"},
+		{name: "dial timeout", err: fmt.Errorf("error sending request: %w", timeoutErr), want: "timeout"},
+		{name: "connection refused", err: fmt.Errorf("error sending request: %w", refusedErr), want: "dial: connection refused"},
+		{name: "unrecognized error unwraps to its cause", err: fmt.Errorf("error sending request: %w", errors.New("boom")), want: "boom"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := networkErrorClass(tc.err); got != tc.want {
+				t.Errorf("networkErrorClass(%v) = %q, want %q", tc.err, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestClassifyErrorDistinguishesNetworkCauses(t *testing.T) {
+	dnsClass := classifyError(&RequestError{Err: fmt.Errorf("error sending request: %w", &net.DNSError{Err: "no such host", Name: "example.invalid"})})
+	refusedClass := classifyError(&RequestError{Err: fmt.Errorf("error sending request: %w", &net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)})})
+
+	if dnsClass == refusedClass {
+		t.Fatalf("dns and connection-refused errors classified identically as %q", dnsClass)
+	}
+
+	if got := classifyError(&RequestError{StatusCode: 404}); got != "http 404" {
+		t.Errorf("classifyError(404) = %q, want %q", got, "http 404")
+	}
+}
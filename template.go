@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+)
+
+// headerSpec is one --header key=tmpl flag.
+type headerSpec struct {
+	Key      string
+	Template string
+}
+
+// headerFlags collects repeated --header flags into a slice of headerSpec.
+type headerFlags []headerSpec
+
+func (h *headerFlags) String() string {
+	parts := make([]string, len(*h))
+	for i, spec := range *h {
+		parts[i] = spec.Key + "=" + spec.Template
+	}
+	return strings.Join(parts, ",")
+}
+
+func (h *headerFlags) Set(value string) error {
+	key, tmpl, ok := strings.Cut(value, "=")
+	if !ok {
+		return fmt.Errorf("invalid -header %q, expected key=template", value)
+	}
+	*h = append(*h, headerSpec{Key: key, Template: tmpl})
+	return nil
+}
+
+type headerTemplate struct {
+	Key      string
+	Template *template.Template
+}
+
+// RequestTemplate builds a per-item method, URL, headers, and body by
+// evaluating Go templates against each decoded JSON item, turning the tool
+// from a bulk-POST script into a general JSON-driven request generator.
+type RequestTemplate struct {
+	Method  *template.Template
+	URL     *template.Template
+	Headers []headerTemplate
+	Body    *template.Template // nil means the raw item is sent as the body
+}
+
+func buildRequestTemplate(config Config) (*RequestTemplate, error) {
+	methodTmpl, err := template.New("method").Option("missingkey=error").Parse(config.Method)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing -method template: %w", err)
+	}
+
+	urlTmpl, err := template.New("url").Option("missingkey=error").Parse(config.EndpointURL)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing -url template: %w", err)
+	}
+
+	headers := make([]headerTemplate, 0, len(config.Headers))
+	for _, spec := range config.Headers {
+		tmpl, err := template.New("header:" + spec.Key).Option("missingkey=error").Parse(spec.Template)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing -header %q template: %w", spec.Key, err)
+		}
+		headers = append(headers, headerTemplate{Key: spec.Key, Template: tmpl})
+	}
+
+	var bodyTmpl *template.Template
+	if config.BodyTemplateFile != "" {
+		raw, err := os.ReadFile(config.BodyTemplateFile)
+		if err != nil {
+			return nil, fmt.Errorf("error reading -body-template: %w", err)
+		}
+		bodyTmpl, err = template.New("body").Option("missingkey=error").Parse(string(raw))
+		if err != nil {
+			return nil, fmt.Errorf("error parsing -body-template: %w", err)
+		}
+	}
+
+	return &RequestTemplate{Method: methodTmpl, URL: urlTmpl, Headers: headers, Body: bodyTmpl}, nil
+}
+
+// Render evaluates the templates against a decoded JSON item, returning the
+// method, URL, headers, and body to send for that item.
+func (rt *RequestTemplate) Render(item json.RawMessage) (method, url string, headers map[string]string, body []byte, err error) {
+	var data interface{}
+	if err := json.Unmarshal(item, &data); err != nil {
+		return "", "", nil, nil, fmt.Errorf("error decoding item for templating: %w", err)
+	}
+
+	if method, err = execTemplate(rt.Method, data); err != nil {
+		return "", "", nil, nil, fmt.Errorf("error rendering method template: %w", err)
+	}
+	if url, err = execTemplate(rt.URL, data); err != nil {
+		return "", "", nil, nil, fmt.Errorf("error rendering url template: %w", err)
+	}
+
+	headers = make(map[string]string, len(rt.Headers))
+	for _, h := range rt.Headers {
+		value, err := execTemplate(h.Template, data)
+		if err != nil {
+			return "", "", nil, nil, fmt.Errorf("error rendering -header %q template: %w", h.Key, err)
+		}
+		headers[h.Key] = value
+	}
+
+	if rt.Body == nil {
+		return method, url, headers, item, nil
+	}
+
+	rendered, err := execTemplate(rt.Body, data)
+	if err != nil {
+		return "", "", nil, nil, fmt.Errorf("error rendering -body-template: %w", err)
+	}
+	return method, url, headers, []byte(rendered), nil
+}
+
+func execTemplate(tmpl *template.Template, data interface{}) (string, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
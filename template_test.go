@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestRequestTemplateRender(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		url        string
+		headers    headerFlags
+		item       string
+		wantMethod string
+		wantURL    string
+		wantHeader map[string]string
+		wantErr    bool
+	}{
+		{
+			name:       "static method and templated url",
+			method:     "PUT",
+			url:        "https://api.example.com/x/{{.id}}",
+			item:       `{"id": "42"}`,
+			wantMethod: "PUT",
+			wantURL:    "https://api.example.com/x/42",
+		},
+		{
+			name:       "templated header",
+			method:     "POST",
+			url:        "https://api.example.com/items",
+			headers:    headerFlags{{Key: "X-Tenant", Template: "{{.tenant}}"}},
+			item:       `{"tenant": "acme"}`,
+			wantMethod: "POST",
+			wantURL:    "https://api.example.com/items",
+			wantHeader: map[string]string{"X-Tenant": "acme"},
+		},
+		{
+			name:    "missing field in url errors instead of rendering <no value>",
+			method:  "PUT",
+			url:     "https://api.example.com/x/{{.id}}",
+			item:    `{"name": "no id here"}`,
+			wantErr: true,
+		},
+		{
+			name:    "missing field in header errors",
+			method:  "POST",
+			url:     "https://api.example.com/items",
+			headers: headerFlags{{Key: "X-Tenant", Template: "{{.tenant}}"}},
+			item:    `{"id": "42"}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rt, err := buildRequestTemplate(Config{Method: tc.method, EndpointURL: tc.url, Headers: tc.headers})
+			if err != nil {
+				t.Fatalf("buildRequestTemplate: %v", err)
+			}
+
+			method, url, headers, _, err := rt.Render(json.RawMessage(tc.item))
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("Render: expected error, got none (method=%q url=%q)", method, url)
+				}
+				if strings.Contains(method, "<no value>") || strings.Contains(url, "<no value>") {
+					t.Fatalf("Render: expected error, rendered <no value> instead: method=%q url=%q", method, url)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Render: unexpected error: %v", err)
+			}
+			if method != tc.wantMethod {
+				t.Errorf("method = %q, want %q", method, tc.wantMethod)
+			}
+			if url != tc.wantURL {
+				t.Errorf("url = %q, want %q", url, tc.wantURL)
+			}
+			for key, want := range tc.wantHeader {
+				if got := headers[key]; got != want {
+					t.Errorf("header %q = %q, want %q", key, got, want)
+				}
+			}
+		})
+	}
+}